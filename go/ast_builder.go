@@ -0,0 +1,22 @@
+package gherkin
+
+// tableRowComments converts a TableRow token's buffered TrailingComments
+// into Comment nodes, for the AstBuilder to attach to the TableRow it
+// builds from that same token. Nothing in this package calls it yet: the
+// AstBuilder that turns a token stream into a GherkinDocument lives
+// outside the files this series touches, so a parsed document still
+// drops interleaved table comments until that builder is updated to call
+// this and attach the result.
+func tableRowComments(token *Token) []*Comment {
+	if len(token.TrailingComments) == 0 {
+		return nil
+	}
+	comments := make([]*Comment, len(token.TrailingComments))
+	for i, span := range token.TrailingComments {
+		comments[i] = &Comment{
+			Location: &Location{token.Location.Line, span.Column},
+			Text:     span.Text,
+		}
+	}
+	return comments
+}
@@ -0,0 +1,118 @@
+package gherkin
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// markdownKeywordPrefixes maps `.feature.md` structural markers onto
+// KeywordPrefixes: an ATX heading marker for titles, a bullet marker for
+// steps, and an optional bold wrap around the keyword.
+var markdownKeywordPrefixes = KeywordPrefixes{
+	TitlePrefixChars: "#",
+	StepPrefixChars:  "-*+",
+	KeywordWrap:      "**",
+}
+
+// markdownMatcher recognizes Gherkin embedded in a `.feature.md` source.
+// Title and step lines are handled by the embedded plain-text matcher via
+// markdownKeywordPrefixes; only constructs with no plain-Gherkin
+// equivalent - prose, fenced doc strings, GFM alignment rows - need
+// handling here.
+type markdownMatcher struct {
+	*matcher
+	headingPattern   *regexp.Regexp
+	bulletPattern    *regexp.Regexp
+	fencePattern     *regexp.Regexp
+	alignmentPattern *regexp.Regexp
+}
+
+func NewMarkdownMatcher(gdp GherkinDialectProvider) Matcher {
+	registry := NewFlavorRegistry(gdp)
+	registry.RegisterDefaultPrefixes(markdownKeywordPrefixes)
+	return &markdownMatcher{
+		matcher:          NewMatcher(registry).(*matcher),
+		headingPattern:   regexp.MustCompile(`^#{1,6}\s+.*$`),
+		bulletPattern:    regexp.MustCompile(`^[-*+]\s+.*$`),
+		fencePattern:     regexp.MustCompile("^```\\s*([a-zA-Z0-9_-]*)\\s*$"),
+		alignmentPattern: regexp.MustCompile(`^\|(\s*:?-+:?\s*\|)+$`),
+	}
+}
+
+// MatchComment is the markdown flavor's catch-all: any non-empty line that
+// isn't a heading, a step bullet, a table row, a fence start or a tag line
+// is prose that belongs to the surrounding document, not the embedded spec.
+// A GFM alignment row counts as prose, so it is skipped inside a table.
+func (m *markdownMatcher) MatchComment(line *Line) (ok bool, token *Token, err error) {
+	if line.IsEmpty() {
+		return
+	}
+	trimmed := strings.Trim(line.trimmedLineText, " ")
+	isStepBullet := false
+	if m.bulletPattern.MatchString(trimmed) {
+		isStepBullet, _, _ = m.matcher.MatchStepLine(line)
+	}
+	if m.headingPattern.MatchString(trimmed) ||
+		isStepBullet ||
+		m.fencePattern.MatchString(trimmed) ||
+		(strings.HasPrefix(trimmed, TABLE_CELL_SEPARATOR) && !m.alignmentPattern.MatchString(trimmed)) ||
+		strings.HasPrefix(trimmed, TAG_PREFIX) {
+		return
+	}
+	token, ok = m.newTokenAtLocation(line.lineNumber, 0), true
+	token.Type = TokenType_Comment
+	token.Text = line.lineText
+	return
+}
+
+// MatchDocStringSeparator treats a fenced code block as a doc string: the
+// opening fence's info string is the content type, and the matching
+// closing ``` fence ends it, mirroring the plain matcher's
+// MatchDocStringSeparator close handling.
+func (m *markdownMatcher) MatchDocStringSeparator(line *Line) (ok bool, token *Token, err error) {
+	if m.activeDocStringSeparator != "" {
+		if strings.Trim(line.trimmedLineText, " ") == DOCSTRING_ALTERNATIVE_SEPARATOR {
+			token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
+			token.Type = TokenType_DocStringSeparator
+
+			m.indentToRemove = 0
+			m.activeDocStringSeparator = ""
+		}
+		return
+	}
+
+	matches := m.fencePattern.FindStringSubmatch(strings.Trim(line.trimmedLineText, " "))
+	if matches == nil {
+		return
+	}
+
+	m.activeDocStringSeparator = DOCSTRING_ALTERNATIVE_SEPARATOR
+	m.indentToRemove = line.Indent()
+	token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
+	token.Type = TokenType_DocStringSeparator
+	token.Text = unescape(matches[1])
+	return
+}
+
+func (m *markdownMatcher) MatchTableRow(line *Line) (ok bool, token *Token, err error) {
+	if !line.StartsWith(TABLE_CELL_SEPARATOR) {
+		return
+	}
+	if m.alignmentPattern.MatchString(strings.Trim(line.trimmedLineText, " ")) {
+		return
+	}
+	return m.matcher.MatchTableRow(line)
+}
+
+// ParseGherkinDocumentInMarkdown parses a `.feature.md` source, wiring the
+// markdown-flavored matcher into the same scanner/parser/AST-builder
+// pipeline that ParseGherkinDocument uses for plain `.feature` sources.
+func ParseGherkinDocumentInMarkdown(in io.Reader) (*GherkinDocument, error) {
+	builder := NewAstBuilder()
+	parser := NewParser(builder)
+	parser.StopAtFirstError = false
+	scanner := NewScanner(in)
+	matcher := NewMarkdownMatcher(DialectsBuiltin())
+	return parser.Parse(scanner, matcher)
+}
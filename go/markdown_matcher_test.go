@@ -0,0 +1,117 @@
+package gherkin
+
+import "testing"
+
+func TestMarkdownMatcherHeadingLevels(t *testing.T) {
+	m := NewMarkdownMatcher(newTestDialectProvider()).(*markdownMatcher)
+
+	cases := []struct {
+		name string
+		line string
+	}{
+		{"h1", "# Feature: ordering"},
+		{"h2", "## Feature: ordering"},
+		{"h6", "###### Feature: ordering"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ok, token, err := m.MatchFeatureLine(newTestLine(1, c.line))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ok {
+				t.Fatalf("expected MatchFeatureLine to match %q", c.line)
+			}
+			if token.Text != "ordering" {
+				t.Errorf("text = %q, want %q", token.Text, "ordering")
+			}
+		})
+	}
+}
+
+func TestMarkdownMatcherRuleHeading(t *testing.T) {
+	m := NewMarkdownMatcher(newTestDialectProvider()).(*markdownMatcher)
+
+	ok, token, err := m.MatchRuleLine(newTestLine(1, "## Rule: only one discount per order"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected MatchRuleLine to match")
+	}
+	if want := "only one discount per order"; token.Text != want {
+		t.Errorf("text = %q, want %q", token.Text, want)
+	}
+}
+
+func TestMarkdownMatcherBoldStep(t *testing.T) {
+	m := NewMarkdownMatcher(newTestDialectProvider()).(*markdownMatcher)
+
+	ok, token, err := m.MatchStepLine(newTestLine(1, "- **Given** a cart with one item"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected MatchStepLine to match a bold bullet step")
+	}
+	if want := "a cart with one item"; token.Text != want {
+		t.Errorf("text = %q, want %q", token.Text, want)
+	}
+
+	ok, token, err = m.MatchStepLine(newTestLine(2, "* And the cart total is shown"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected MatchStepLine to match a plain bullet step")
+	}
+	if want := "the cart total is shown"; token.Text != want {
+		t.Errorf("text = %q, want %q", token.Text, want)
+	}
+}
+
+func TestMarkdownMatcherFencedDocString(t *testing.T) {
+	m := NewMarkdownMatcher(newTestDialectProvider()).(*markdownMatcher)
+
+	ok, token, err := m.MatchDocStringSeparator(newTestLine(1, "```json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected MatchDocStringSeparator to match the opening fence")
+	}
+	if token.Text != "json" {
+		t.Errorf("content type = %q, want %q", token.Text, "json")
+	}
+
+	ok, _, err = m.MatchDocStringSeparator(newTestLine(2, "```"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected MatchDocStringSeparator to match the closing fence")
+	}
+}
+
+func TestMarkdownMatcherSkipsAlignmentRow(t *testing.T) {
+	m := NewMarkdownMatcher(newTestDialectProvider()).(*markdownMatcher)
+
+	ok, _, err := m.MatchTableRow(newTestLine(1, "| :--- | ---: |"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatalf("expected MatchTableRow to skip a GFM alignment row")
+	}
+
+	ok, token, err := m.MatchTableRow(newTestLine(2, "| a | b |"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected MatchTableRow to match a data row")
+	}
+	if len(token.Items) != 2 {
+		t.Fatalf("got %d cells, want 2", len(token.Items))
+	}
+}
@@ -13,24 +13,161 @@ const (
 	TABLE_CELL_SEPARATOR            = "|"
 	DOCSTRING_SEPARATOR             = "\"\"\""
 	DOCSTRING_ALTERNATIVE_SEPARATOR = "```"
+	ESCAPE_CHAR                     = '\\'
+	ESCAPED_NEWLINE                 = 'n'
 )
 
+// unescape resolves the \|, \\ and \n escape sequences honored inside table
+// cells and doc string content types, leaving any other backslash sequence
+// untouched so callers can still round-trip unrecognized escapes.
+func unescape(s string) string {
+	if !strings.ContainsRune(s, ESCAPE_CHAR) {
+		return s
+	}
+	var b strings.Builder
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if escaped {
+			switch c {
+			case ESCAPED_NEWLINE:
+				b.WriteByte('\n')
+			case TABLE_CELL_SEPARATOR[0], ESCAPE_CHAR:
+				b.WriteByte(c)
+			default:
+				b.WriteByte(ESCAPE_CHAR)
+				b.WriteByte(c)
+			}
+			escaped = false
+			continue
+		}
+		if c == ESCAPE_CHAR {
+			escaped = true
+			continue
+		}
+		b.WriteByte(c)
+	}
+	if escaped {
+		b.WriteByte(ESCAPE_CHAR)
+	}
+	return b.String()
+}
+
 type matcher struct {
 	gdp                      GherkinDialectProvider
 	lang                     string
 	dialect                  *GherkinDialect
+	prefixes                 KeywordPrefixes
 	activeDocStringSeparator string
 	indentToRemove           int
 	languagePattern          *regexp.Regexp
+	bufferTableRowComments   bool
+	inTableContext           bool
+	pendingTableComments     []*LineSpan
+}
+
+// MatcherOption configures optional matcher behavior at construction time.
+type MatcherOption func(*matcher)
+
+// WithTableRowComments opts into buffering `#`-prefixed comment lines that
+// appear between data table rows (or Examples table rows) and attaching
+// them as TrailingComments on the next matched TableRow token. Nothing
+// reads that field back out of the token stream yet - see tableRowComments
+// in ast_builder.go for the conversion still waiting on an AstBuilder to
+// call it.
+func WithTableRowComments() MatcherOption {
+	return func(m *matcher) {
+		m.bufferTableRowComments = true
+	}
 }
 
-func NewMatcher(gdp GherkinDialectProvider) Matcher {
-	return &matcher{
+func NewMatcher(gdp GherkinDialectProvider, options ...MatcherOption) Matcher {
+	m := &matcher{
 		gdp:             gdp,
 		lang:            DEFAULT_DIALECT,
 		dialect:         gdp.GetDialect(DEFAULT_DIALECT),
+		prefixes:        prefixesFor(gdp, DEFAULT_DIALECT),
 		languagePattern: regexp.MustCompile("^\\s*#\\s*language\\s*:\\s*([a-zA-Z\\-_]+)\\s*$"),
 	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+func (m *matcher) resetTableCommentBuffering() {
+	m.inTableContext = false
+	m.pendingTableComments = nil
+}
+
+// KeywordPrefixes customizes the markers a flavor wraps its structural
+// keywords in: chars repeated before a title keyword, chars before a step
+// keyword, and a pair wrapping the keyword itself. The zero value matches
+// plain Gherkin's bare `Keyword:`/`Keyword ` lines.
+type KeywordPrefixes struct {
+	TitlePrefixChars string
+	StepPrefixChars  string
+	KeywordWrap      string
+}
+
+// FlavorPrefixProvider is implemented by dialect providers, such as
+// FlavorRegistry, that also know a flavor's KeywordPrefixes.
+type FlavorPrefixProvider interface {
+	GetKeywordPrefixes(name string) KeywordPrefixes
+}
+
+func prefixesFor(gdp GherkinDialectProvider, name string) KeywordPrefixes {
+	if fp, ok := gdp.(FlavorPrefixProvider); ok {
+		return fp.GetKeywordPrefixes(name)
+	}
+	return KeywordPrefixes{}
+}
+
+// FlavorRegistry registers additional Gherkin flavors - a dialect plus the
+// KeywordPrefixes that identify its structural lines - on top of a wrapped
+// GherkinDialectProvider, and is itself a drop-in GherkinDialectProvider.
+type FlavorRegistry struct {
+	gdp             GherkinDialectProvider
+	dialects        map[string]*GherkinDialect
+	prefixes        map[string]KeywordPrefixes
+	defaultPrefixes *KeywordPrefixes
+}
+
+func NewFlavorRegistry(gdp GherkinDialectProvider) *FlavorRegistry {
+	return &FlavorRegistry{
+		gdp:      gdp,
+		dialects: make(map[string]*GherkinDialect),
+		prefixes: make(map[string]KeywordPrefixes),
+	}
+}
+
+func (r *FlavorRegistry) Register(name string, dialect *GherkinDialect, prefixes KeywordPrefixes) {
+	r.dialects[name] = dialect
+	r.prefixes[name] = prefixes
+}
+
+// RegisterDefaultPrefixes sets the KeywordPrefixes returned for any name
+// without its own Register call, for a flavor whose markers don't vary
+// per language.
+func (r *FlavorRegistry) RegisterDefaultPrefixes(prefixes KeywordPrefixes) {
+	r.defaultPrefixes = &prefixes
+}
+
+func (r *FlavorRegistry) GetDialect(name string) *GherkinDialect {
+	if dialect, ok := r.dialects[name]; ok {
+		return dialect
+	}
+	return r.gdp.GetDialect(name)
+}
+
+func (r *FlavorRegistry) GetKeywordPrefixes(name string) KeywordPrefixes {
+	if prefixes, ok := r.prefixes[name]; ok {
+		return prefixes
+	}
+	if r.defaultPrefixes != nil {
+		return *r.defaultPrefixes
+	}
+	return KeywordPrefixes{}
 }
 
 func (m *matcher) newTokenAtLocation(line, index int) (token *Token) {
@@ -53,6 +190,7 @@ func (m *matcher) MatchEmpty(line *Line) (ok bool, token *Token, err error) {
 	if line.IsEmpty() {
 		token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
 		token.Type = TokenType_Empty
+		m.resetTableCommentBuffering()
 	}
 	return
 }
@@ -62,6 +200,10 @@ func (m *matcher) MatchComment(line *Line) (ok bool, token *Token, err error) {
 		token, ok = m.newTokenAtLocation(line.lineNumber, 0), true
 		token.Type = TokenType_Comment
 		token.Text = line.lineText
+
+		if m.bufferTableRowComments && m.inTableContext {
+			m.pendingTableComments = append(m.pendingTableComments, &LineSpan{line.Indent(), line.lineText})
+		}
 	}
 	return
 }
@@ -82,20 +224,48 @@ func (m *matcher) MatchTagLine(line *Line) (ok bool, token *Token, err error) {
 		token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
 		token.Type = TokenType_TagLine
 		token.Items = tags
+		m.resetTableCommentBuffering()
 	}
 	return
 }
 
+// matchKeyword reports whether text starts with keyword, honoring an
+// optional KeywordWrap (e.g. Markdown's "**bold**") around it, and
+// returns the length of text consumed by the keyword.
+func (m *matcher) matchKeyword(text, keyword string) (n int, ok bool) {
+	if wrap := m.prefixes.KeywordWrap; wrap != "" {
+		// step keywords carry a trailing space, which sits outside the wrap
+		if wrapped := wrap + strings.TrimRight(keyword, " ") + wrap; strings.HasPrefix(text, wrapped) {
+			return len(wrapped), true
+		}
+	}
+	if strings.HasPrefix(text, keyword) {
+		return len(keyword), true
+	}
+	return 0, false
+}
+
 func (m *matcher) matchTitleLine(line *Line, tokenType TokenType, keywords []string) (ok bool, token *Token, err error) {
+	text := line.trimmedLineText
+	if chars := m.prefixes.TitlePrefixChars; chars != "" {
+		stripped := strings.TrimLeft(text, chars)
+		if stripped == text {
+			return
+		}
+		text = strings.TrimLeft(stripped, " ")
+	}
 	for i := range keywords {
 		keyword := keywords[i]
-		if line.StartsWith(keyword + TITLE_KEYWORD_SEPARATOR) {
-			token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
-			token.Type = tokenType
-			token.Keyword = keyword
-			token.Text = strings.Trim(line.trimmedLineText[len(keyword)+1:], " ")
-			return
+		n, matched := m.matchKeyword(text, keyword)
+		if !matched || !strings.HasPrefix(text[n:], TITLE_KEYWORD_SEPARATOR) {
+			continue
 		}
+		token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
+		token.Type = tokenType
+		token.Keyword = keyword
+		token.Text = strings.Trim(text[n+len(TITLE_KEYWORD_SEPARATOR):], " ")
+		m.resetTableCommentBuffering()
+		return
 	}
 	return
 }
@@ -112,20 +282,39 @@ func (m *matcher) MatchScenarioLine(line *Line) (ok bool, token *Token, err erro
 func (m *matcher) MatchScenarioOutlineLine(line *Line) (ok bool, token *Token, err error) {
 	return m.matchTitleLine(line, TokenType_ScenarioOutlineLine, m.dialect.ScenarioOutlineKeywords())
 }
+func (m *matcher) MatchRuleLine(line *Line) (ok bool, token *Token, err error) {
+	return m.matchTitleLine(line, TokenType_RuleLine, m.dialect.RuleKeywords())
+}
 func (m *matcher) MatchExamplesLine(line *Line) (ok bool, token *Token, err error) {
-	return m.matchTitleLine(line, TokenType_ExamplesLine, m.dialect.ExamplesKeywords())
+	ok, token, err = m.matchTitleLine(line, TokenType_ExamplesLine, m.dialect.ExamplesKeywords())
+	if ok {
+		// comments buffered under an Examples: line still flush onto its rows
+		m.inTableContext = m.bufferTableRowComments
+	}
+	return
 }
 func (m *matcher) MatchStepLine(line *Line) (ok bool, token *Token, err error) {
+	text := line.trimmedLineText
+	if chars := m.prefixes.StepPrefixChars; chars != "" {
+		stripped := strings.TrimLeft(text, chars)
+		if stripped == text {
+			return
+		}
+		text = strings.TrimLeft(stripped, " ")
+	}
 	keywords := m.dialect.StepKeywords()
 	for i := range keywords {
 		keyword := keywords[i]
-		if line.StartsWith(keyword) {
-			token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
-			token.Type = TokenType_StepLine
-			token.Keyword = keyword
-			token.Text = strings.Trim(line.trimmedLineText[len(keyword):], " ")
-			return
+		n, matched := m.matchKeyword(text, keyword)
+		if !matched {
+			continue
 		}
+		token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
+		token.Type = TokenType_StepLine
+		token.Keyword = keyword
+		token.Text = strings.Trim(text[n:], " ")
+		m.resetTableCommentBuffering()
+		return
 	}
 	return
 }
@@ -149,11 +338,12 @@ func (m *matcher) MatchDocStringSeparator(line *Line) (ok bool, token *Token, er
 	}
 	if m.activeDocStringSeparator != "" {
 		// open
-		contentType := line.trimmedLineText[len(m.activeDocStringSeparator):]
+		contentType := unescape(line.trimmedLineText[len(m.activeDocStringSeparator):])
 		m.indentToRemove = line.Indent()
 		token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
 		token.Type = TokenType_DocStringSeparator
 		token.Text = contentType
+		m.resetTableCommentBuffering()
 	}
 	return
 }
@@ -163,23 +353,52 @@ func (m *matcher) MatchTableRow(line *Line) (ok bool, token *Token, err error) {
 		var cells []*LineSpan
 		var column = line.Indent() + 1
 		ttxt := strings.Trim(line.trimmedLineText, " ")
-		splits := strings.Split(ttxt[1:len(ttxt)-1], TABLE_CELL_SEPARATOR)
-		for i := range splits {
+		inner := ttxt[1 : len(ttxt)-1]
+
+		addCell := func(raw string, startColumn int) {
 			ind := 0
-			txt := splits[i]
-			for k := range txt {
-				if txt[k:k+1] != " " {
+			for k := range raw {
+				if raw[k:k+1] != " " {
 					break
 				}
 				ind++
 			}
-			cells = append(cells, &LineSpan{column + ind + 1, strings.Trim(splits[i], " ")})
-			column = column + len(txt) + 1
+			cells = append(cells, &LineSpan{startColumn + ind + 1, strings.Trim(unescape(raw), " ")})
+		}
+
+		var cell strings.Builder
+		escaped := false
+		for i := 0; i < len(inner); i++ {
+			c := inner[i]
+			switch {
+			case escaped:
+				cell.WriteByte(c)
+				escaped = false
+			case c == ESCAPE_CHAR:
+				cell.WriteByte(c)
+				escaped = true
+			case c == TABLE_CELL_SEPARATOR[0]:
+				raw := cell.String()
+				addCell(raw, column)
+				column = column + len(raw) + 1
+				cell.Reset()
+			default:
+				cell.WriteByte(c)
+			}
 		}
+		addCell(cell.String(), column)
 
 		token, ok = m.newTokenAtLocation(line.lineNumber, line.Indent()), true
 		token.Type = TokenType_TableRow
 		token.Items = cells
+
+		if m.bufferTableRowComments {
+			if len(m.pendingTableComments) > 0 {
+				token.TrailingComments = m.pendingTableComments
+				m.pendingTableComments = nil
+			}
+			m.inTableContext = true
+		}
 	}
 	return
 }
@@ -198,6 +417,7 @@ func (m *matcher) MatchLanguage(line *Line) (ok bool, token *Token, err error) {
 		} else {
 			m.lang = lang
 			m.dialect = dialect
+			m.prefixes = prefixesFor(m.gdp, lang)
 		}
 	}
 	return
@@ -220,4 +440,4 @@ func (m *matcher) MatchOther(line *Line) (ok bool, token *Token, err error) {
 	}
 	token.Text = txt[ind:]
 	return
-}
\ No newline at end of file
+}
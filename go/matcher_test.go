@@ -0,0 +1,191 @@
+package gherkin
+
+import "testing"
+
+// newTestLine and newTestDialectProvider lean on the lexer/dialects
+// building blocks that live alongside this matcher in the full module.
+func newTestLine(lineNumber int, text string) *Line {
+	return NewLine(text, lineNumber)
+}
+
+func newTestDialectProvider() GherkinDialectProvider {
+	return &DialectsGherkinDialectProvider{}
+}
+
+func TestUnescape(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no escapes", "plain text", "plain text"},
+		{"escaped pipe", `a\|b`, "a|b"},
+		{"escaped backslash", `a\\b`, `a\b`},
+		{"escaped newline", `a\nb`, "a\nb"},
+		{"unknown escape is preserved", `a\tb`, `a\tb`},
+		{"trailing lone backslash", `a\`, `a\`},
+		{"multiple escapes", `\|\\\n`, "|\\\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := unescape(c.in); got != c.want {
+				t.Errorf("unescape(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMatchTableRowEscapes(t *testing.T) {
+	m := NewMatcher(newTestDialectProvider()).(*matcher)
+
+	cases := []struct {
+		name      string
+		line      string
+		wantCells []string
+	}{
+		{
+			name:      "escaped pipe inside cell",
+			line:      `| a\|b | c |`,
+			wantCells: []string{"a|b", "c"},
+		},
+		{
+			name:      "escaped backslash inside cell",
+			line:      `| a\\b | c |`,
+			wantCells: []string{`a\b`, "c"},
+		},
+		{
+			name:      "escaped newline inside cell",
+			line:      `| a\nb | c |`,
+			wantCells: []string{"a\nb", "c"},
+		},
+		{
+			name:      "unbalanced trailing backslash",
+			line:      `| a\ | c |`,
+			wantCells: []string{`a\`, "c"},
+		},
+		{
+			name:      "escape at cell boundary",
+			line:      `|\|a|b\||`,
+			wantCells: []string{"|a", `b|`},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ok, token, err := m.MatchTableRow(newTestLine(1, c.line))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ok {
+				t.Fatalf("expected MatchTableRow to match %q", c.line)
+			}
+			if len(token.Items) != len(c.wantCells) {
+				t.Fatalf("got %d cells, want %d", len(token.Items), len(c.wantCells))
+			}
+			for i, want := range c.wantCells {
+				if token.Items[i].Text != want {
+					t.Errorf("cell %d = %q, want %q", i, token.Items[i].Text, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchTableRowBuffersInterleavedComments(t *testing.T) {
+	m := NewMatcher(newTestDialectProvider(), WithTableRowComments()).(*matcher)
+
+	ok, _, err := m.MatchTableRow(newTestLine(1, "| a | b |"))
+	if err != nil || !ok {
+		t.Fatalf("MatchTableRow(header) = %v, %v, want ok", ok, err)
+	}
+
+	ok, _, err = m.MatchComment(newTestLine(2, "# explains the next row"))
+	if err != nil || !ok {
+		t.Fatalf("MatchComment = %v, %v, want ok", ok, err)
+	}
+
+	ok, token, err := m.MatchTableRow(newTestLine(3, "| 1 | 2 |"))
+	if err != nil || !ok {
+		t.Fatalf("MatchTableRow(data) = %v, %v, want ok", ok, err)
+	}
+	if len(token.TrailingComments) != 1 || token.TrailingComments[0].Text != "# explains the next row" {
+		t.Errorf("TrailingComments = %+v, want one comment with the buffered text", token.TrailingComments)
+	}
+
+	comments := tableRowComments(token)
+	if len(comments) != 1 {
+		t.Fatalf("tableRowComments = %+v, want one Comment", comments)
+	}
+	if comments[0].Text != "# explains the next row" {
+		t.Errorf("comment text = %q, want %q", comments[0].Text, "# explains the next row")
+	}
+	if comments[0].Location.Line != 3 {
+		t.Errorf("comment location line = %d, want the row's own line 3", comments[0].Location.Line)
+	}
+}
+
+func TestMatchTableRowDropsCommentsAfterLeavingTableContext(t *testing.T) {
+	m := NewMatcher(newTestDialectProvider(), WithTableRowComments()).(*matcher)
+
+	ok, _, err := m.MatchTableRow(newTestLine(1, "| a | b |"))
+	if err != nil || !ok {
+		t.Fatalf("MatchTableRow(header) = %v, %v, want ok", ok, err)
+	}
+	ok, _, err = m.MatchComment(newTestLine(2, "# orphaned comment"))
+	if err != nil || !ok {
+		t.Fatalf("MatchComment = %v, %v, want ok", ok, err)
+	}
+	ok, _, err = m.MatchEmpty(newTestLine(3, ""))
+	if err != nil || !ok {
+		t.Fatalf("MatchEmpty = %v, %v, want ok", ok, err)
+	}
+
+	ok, token, err := m.MatchTableRow(newTestLine(4, "| 1 | 2 |"))
+	if err != nil || !ok {
+		t.Fatalf("MatchTableRow(data) = %v, %v, want ok", ok, err)
+	}
+	if len(token.TrailingComments) != 0 {
+		t.Errorf("TrailingComments = %+v, want none once table context was left", token.TrailingComments)
+	}
+}
+
+func TestFlavorRegistryFallsBackToWrappedProvider(t *testing.T) {
+	base := newTestDialectProvider()
+	registry := NewFlavorRegistry(base)
+
+	if got, want := registry.GetDialect(DEFAULT_DIALECT), base.GetDialect(DEFAULT_DIALECT); got != want {
+		t.Errorf("GetDialect(%q) = %v, want the wrapped provider's dialect", DEFAULT_DIALECT, got)
+	}
+	if got := registry.GetKeywordPrefixes(DEFAULT_DIALECT); got != (KeywordPrefixes{}) {
+		t.Errorf("GetKeywordPrefixes(%q) = %+v, want the zero value", DEFAULT_DIALECT, got)
+	}
+}
+
+func TestFlavorRegistryRegisteredNameTakesPriorityOverDefault(t *testing.T) {
+	base := newTestDialectProvider()
+	registry := NewFlavorRegistry(base)
+	registry.RegisterDefaultPrefixes(KeywordPrefixes{TitlePrefixChars: "#"})
+	registry.Register("custom", base.GetDialect(DEFAULT_DIALECT), KeywordPrefixes{StepPrefixChars: "-"})
+
+	if got, want := registry.GetKeywordPrefixes("custom"), (KeywordPrefixes{StepPrefixChars: "-"}); got != want {
+		t.Errorf("GetKeywordPrefixes(%q) = %+v, want %+v", "custom", got, want)
+	}
+	if got, want := registry.GetKeywordPrefixes("other"), (KeywordPrefixes{TitlePrefixChars: "#"}); got != want {
+		t.Errorf("GetKeywordPrefixes(%q) = %+v, want the default %+v", "other", got, want)
+	}
+}
+
+func TestMatchDocStringSeparatorUnescapesContentType(t *testing.T) {
+	m := NewMatcher(newTestDialectProvider()).(*matcher)
+
+	ok, token, err := m.MatchDocStringSeparator(newTestLine(1, `"""json\|escaped`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected MatchDocStringSeparator to match the opening separator")
+	}
+	if want := "json|escaped"; token.Text != want {
+		t.Errorf("content type = %q, want %q", token.Text, want)
+	}
+}
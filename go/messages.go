@@ -0,0 +1,278 @@
+package gherkin
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Envelope mirrors the cucumber-messages envelope: at most one of Source,
+// GherkinDocument, Pickle or ParseError is populated.
+type Envelope struct {
+	Source          *Source            `json:"source,omitempty"`
+	GherkinDocument *GherkinDocument   `json:"gherkinDocument,omitempty"`
+	Pickle          *Pickle            `json:"pickle,omitempty"`
+	ParseError      *ParseErrorMessage `json:"parseError,omitempty"`
+}
+
+// Source carries the raw text of one parsed file, keyed by its uri.
+type Source struct {
+	Uri       string `json:"uri"`
+	Data      string `json:"data"`
+	MediaType string `json:"mediaType"`
+}
+
+// ParseErrorMessage reports a parse failure for one uri.
+type ParseErrorMessage struct {
+	Uri     string `json:"uri"`
+	Message string `json:"message"`
+}
+
+var languageDirectivePattern = regexp.MustCompile(`^\s*#\s*language\s*:`)
+
+func hasLanguageDirective(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if languageDirectivePattern.MatchString(trimmed) {
+			return true
+		}
+		if strings.HasPrefix(trimmed, COMMENT_PREFIX) {
+			continue
+		}
+		return false
+	}
+	return false
+}
+
+// Messages parses each file in paths (or, when paths is empty, the single
+// source read from sourceStream) and writes one envelope per line to out,
+// as JSON when asJSON is true or otherwise as the envelope's JSON bytes
+// framed with a 4-byte big-endian length prefix. language sets the
+// dialect used for sources without their own `# language:` directive.
+func Messages(paths []string, sourceStream io.Reader, language string, includeSource, includeGherkinDocument, includePickles bool, out io.Writer, asJSON bool) ([]Envelope, error) {
+	var envelopes []Envelope
+
+	emit := func(e Envelope) error {
+		envelopes = append(envelopes, e)
+		if out == nil {
+			return nil
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			_, err = out.Write(append(data, '\n'))
+			return err
+		}
+		header := make([]byte, 4)
+		binary.BigEndian.PutUint32(header, uint32(len(data)))
+		if _, err := out.Write(header); err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	}
+
+	parseOne := func(uri string, r io.Reader) error {
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		content := string(raw)
+
+		if includeSource {
+			if err := emit(Envelope{Source: &Source{Uri: uri, Data: content, MediaType: "text/x.cucumber.gherkin+plain"}}); err != nil {
+				return err
+			}
+		}
+
+		if language != "" && !hasLanguageDirective(content) {
+			content = "# language: " + language + "\n" + content
+		}
+
+		doc, parseErr := ParseGherkinDocument(strings.NewReader(content))
+		if parseErr != nil {
+			return emit(Envelope{ParseError: &ParseErrorMessage{Uri: uri, Message: parseErr.Error()}})
+		}
+		doc.Uri = uri
+
+		if includeGherkinDocument {
+			if err := emit(Envelope{GherkinDocument: doc}); err != nil {
+				return err
+			}
+		}
+
+		if includePickles {
+			for _, pickle := range Pickles(doc, uri, content) {
+				if err := emit(Envelope{Pickle: pickle}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if len(paths) == 0 {
+		return envelopes, parseOne("", sourceStream)
+	}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return envelopes, err
+		}
+		err = parseOne(path, f)
+		f.Close()
+		if err != nil {
+			return envelopes, err
+		}
+	}
+	return envelopes, nil
+}
+
+var placeholderPattern = regexp.MustCompile(`<([^>]+)>`)
+
+// interpolate substitutes every `<name>` placeholder in text with the
+// value of the matching Examples column, leaving unknown placeholders
+// untouched.
+func interpolate(text string, variableCells, valueCells []*TableCell) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := match[1 : len(match)-1]
+		for i, variable := range variableCells {
+			if variable.Value == name && i < len(valueCells) {
+				return valueCells[i].Value
+			}
+		}
+		return match
+	})
+}
+
+func pickleStep(step *Step, variableCells, valueCells []*TableCell) *PickleStep {
+	pickleStep := &PickleStep{
+		Text:       interpolate(step.Text, variableCells, valueCells),
+		AstNodeIds: []string{step.Id},
+	}
+
+	if step.DocString != nil {
+		pickleStep.DocString = &PickleDocString{
+			MediaType: step.DocString.MediaType,
+			Content:   interpolate(step.DocString.Content, variableCells, valueCells),
+		}
+	}
+
+	if step.DataTable != nil {
+		var rows []*PickleTableRow
+		for _, row := range step.DataTable.Rows {
+			var cells []*PickleTableCell
+			for _, cell := range row.Cells {
+				cells = append(cells, &PickleTableCell{Value: interpolate(cell.Value, variableCells, valueCells)})
+			}
+			rows = append(rows, &PickleTableRow{Cells: cells})
+		}
+		pickleStep.DataTable = &PickleTable{Rows: rows}
+	}
+
+	return pickleStep
+}
+
+func pickleSteps(steps []*Step, variableCells, valueCells []*TableCell) []*PickleStep {
+	var pickleSteps []*PickleStep
+	for _, step := range steps {
+		pickleSteps = append(pickleSteps, pickleStep(step, variableCells, valueCells))
+	}
+	return pickleSteps
+}
+
+func pickleTags(tags []*Tag) []*PickleTag {
+	var pickleTags []*PickleTag
+	for _, tag := range tags {
+		pickleTags = append(pickleTags, &PickleTag{Name: tag.Name, AstNodeId: tag.Id})
+	}
+	return pickleTags
+}
+
+// Pickles expands doc's scenarios into the flattened list a runner
+// drives: each Scenario Outline is expanded once per Examples row, with
+// `<param>` placeholders substituted into step text, doc strings and
+// table cells, and Background steps (if any) inlined ahead of each
+// pickle's own. Scenarios nested inside a Rule are expanded the same way,
+// with the Rule's own Background steps inlined after the Feature's.
+func Pickles(doc *GherkinDocument, uri, source string) []*Pickle {
+	if doc == nil || doc.Feature == nil {
+		return nil
+	}
+
+	feature := doc.Feature
+	var backgroundSteps []*Step
+	var pickles []*Pickle
+
+	for _, child := range feature.Children {
+		switch {
+		case child.Background != nil:
+			backgroundSteps = child.Background.Steps
+		case child.Scenario != nil:
+			pickles = append(pickles, scenarioPickles(child.Scenario, backgroundSteps, feature.Tags, feature.Language, uri)...)
+		case child.Rule != nil:
+			pickles = append(pickles, rulePickles(child.Rule, backgroundSteps, feature.Tags, feature.Language, uri)...)
+		}
+	}
+
+	return pickles
+}
+
+func rulePickles(rule *Rule, featureBackgroundSteps []*Step, featureTags []*Tag, language, uri string) []*Pickle {
+	backgroundSteps := featureBackgroundSteps
+	var pickles []*Pickle
+
+	for _, child := range rule.Children {
+		switch {
+		case child.Background != nil:
+			backgroundSteps = append(append([]*Step{}, featureBackgroundSteps...), child.Background.Steps...)
+		case child.Scenario != nil:
+			pickles = append(pickles, scenarioPickles(child.Scenario, backgroundSteps, featureTags, language, uri)...)
+		}
+	}
+
+	return pickles
+}
+
+func scenarioPickles(scenario *Scenario, backgroundSteps []*Step, featureTags []*Tag, language, uri string) []*Pickle {
+	allSteps := append(append([]*Step{}, backgroundSteps...), scenario.Steps...)
+
+	if len(scenario.Examples) == 0 {
+		return []*Pickle{{
+			Uri:        uri,
+			Name:       scenario.Text,
+			Language:   language,
+			Steps:      pickleSteps(allSteps, nil, nil),
+			Tags:       pickleTags(append(featureTags, scenario.Tags...)),
+			AstNodeIds: []string{scenario.Id},
+		}}
+	}
+
+	var pickles []*Pickle
+	for _, examples := range scenario.Examples {
+		if examples.TableHeader == nil {
+			continue
+		}
+		variableCells := examples.TableHeader.Cells
+		for _, valuesRow := range examples.TableBody {
+			pickles = append(pickles, &Pickle{
+				Uri:        uri,
+				Name:       scenario.Text,
+				Language:   language,
+				Steps:      pickleSteps(allSteps, variableCells, valuesRow.Cells),
+				Tags:       pickleTags(append(append(featureTags, scenario.Tags...), examples.Tags...)),
+				AstNodeIds: []string{scenario.Id, valuesRow.Id},
+			})
+		}
+	}
+	return pickles
+}
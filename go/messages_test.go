@@ -0,0 +1,154 @@
+package gherkin
+
+import "testing"
+
+func TestPicklesExpandsExamplesAndInlinesBackground(t *testing.T) {
+	doc := &GherkinDocument{
+		Feature: &Feature{
+			Language: "en",
+			Tags:     []*Tag{{Name: "@feature-tag", Id: "t0"}},
+			Children: []*FeatureChild{
+				{Background: &Background{
+					Steps: []*Step{{Id: "s0", Text: "a cart"}},
+				}},
+				{Scenario: &Scenario{
+					Id:   "sc0",
+					Text: "checkout",
+					Tags: []*Tag{{Name: "@scenario-tag", Id: "t1"}},
+					Steps: []*Step{
+						{Id: "s1", Text: "I add <item> to the cart"},
+					},
+					Examples: []*Examples{{
+						TableHeader: &TableRow{Cells: []*TableCell{{Value: "item"}}},
+						TableBody: []*TableRow{
+							{Id: "r0", Cells: []*TableCell{{Value: "apple"}}},
+							{Id: "r1", Cells: []*TableCell{{Value: "pear"}}},
+						},
+					}},
+				}},
+			},
+		},
+	}
+
+	pickles := Pickles(doc, "checkout.feature", "")
+	if len(pickles) != 2 {
+		t.Fatalf("got %d pickles, want 2", len(pickles))
+	}
+
+	first := pickles[0]
+	if len(first.Steps) != 2 {
+		t.Fatalf("got %d steps, want the Background step inlined ahead of the scenario's own", len(first.Steps))
+	}
+	if first.Steps[0].Text != "a cart" {
+		t.Errorf("steps[0].Text = %q, want the inlined Background step", first.Steps[0].Text)
+	}
+	if want := "I add apple to the cart"; first.Steps[1].Text != want {
+		t.Errorf("steps[1].Text = %q, want %q", first.Steps[1].Text, want)
+	}
+	if want := "I add pear to the cart"; pickles[1].Steps[1].Text != want {
+		t.Errorf("pickles[1].Steps[1].Text = %q, want %q", pickles[1].Steps[1].Text, want)
+	}
+
+	wantAstNodeIds := []string{"sc0", "r0"}
+	if len(first.AstNodeIds) != len(wantAstNodeIds) || first.AstNodeIds[0] != wantAstNodeIds[0] || first.AstNodeIds[1] != wantAstNodeIds[1] {
+		t.Errorf("AstNodeIds = %v, want %v", first.AstNodeIds, wantAstNodeIds)
+	}
+
+	wantTagNames := map[string]bool{"@feature-tag": true, "@scenario-tag": true}
+	if len(first.Tags) != len(wantTagNames) {
+		t.Fatalf("got %d tags, want %d", len(first.Tags), len(wantTagNames))
+	}
+	for _, tag := range first.Tags {
+		if !wantTagNames[tag.Name] {
+			t.Errorf("unexpected tag %q", tag.Name)
+		}
+	}
+}
+
+func TestPicklesWithoutExamplesYieldsOnePickle(t *testing.T) {
+	doc := &GherkinDocument{
+		Feature: &Feature{
+			Children: []*FeatureChild{
+				{Scenario: &Scenario{
+					Id:    "sc0",
+					Text:  "a plain scenario",
+					Steps: []*Step{{Id: "s0", Text: "something happens"}},
+				}},
+			},
+		},
+	}
+
+	pickles := Pickles(doc, "plain.feature", "")
+	if len(pickles) != 1 {
+		t.Fatalf("got %d pickles, want 1", len(pickles))
+	}
+	if pickles[0].Steps[0].Text != "something happens" {
+		t.Errorf("step text = %q, want the scenario's own step unchanged", pickles[0].Steps[0].Text)
+	}
+	if len(pickles[0].AstNodeIds) != 1 || pickles[0].AstNodeIds[0] != "sc0" {
+		t.Errorf("AstNodeIds = %v, want [sc0]", pickles[0].AstNodeIds)
+	}
+}
+
+func TestPicklesIncludesRuleScopedScenarios(t *testing.T) {
+	doc := &GherkinDocument{
+		Feature: &Feature{
+			Language: "en",
+			Tags:     []*Tag{{Name: "@feature-tag", Id: "t0"}},
+			Children: []*FeatureChild{
+				{Background: &Background{
+					Steps: []*Step{{Id: "s0", Text: "a cart"}},
+				}},
+				{Rule: &Rule{
+					Id: "r0",
+					Children: []*RuleChild{
+						{Background: &Background{
+							Steps: []*Step{{Id: "s1", Text: "a discount code"}},
+						}},
+						{Scenario: &Scenario{
+							Id:    "sc0",
+							Text:  "checkout with a discount",
+							Tags:  []*Tag{{Name: "@scenario-tag", Id: "t1"}},
+							Steps: []*Step{{Id: "s2", Text: "I check out"}},
+						}},
+					},
+				}},
+			},
+		},
+	}
+
+	pickles := Pickles(doc, "rules.feature", "")
+	if len(pickles) != 1 {
+		t.Fatalf("got %d pickles, want 1", len(pickles))
+	}
+
+	pickle := pickles[0]
+	if len(pickle.Steps) != 3 {
+		t.Fatalf("got %d steps, want the Feature Background, Rule Background and scenario's own step", len(pickle.Steps))
+	}
+	if want := "a cart"; pickle.Steps[0].Text != want {
+		t.Errorf("steps[0].Text = %q, want the inlined Feature Background step %q", pickle.Steps[0].Text, want)
+	}
+	if want := "a discount code"; pickle.Steps[1].Text != want {
+		t.Errorf("steps[1].Text = %q, want the inlined Rule Background step %q", pickle.Steps[1].Text, want)
+	}
+	if want := "I check out"; pickle.Steps[2].Text != want {
+		t.Errorf("steps[2].Text = %q, want the scenario's own step %q", pickle.Steps[2].Text, want)
+	}
+
+	wantTagNames := map[string]bool{"@feature-tag": true, "@scenario-tag": true}
+	if len(pickle.Tags) != len(wantTagNames) {
+		t.Fatalf("got %d tags, want %d", len(pickle.Tags), len(wantTagNames))
+	}
+	for _, tag := range pickle.Tags {
+		if !wantTagNames[tag.Name] {
+			t.Errorf("unexpected tag %q", tag.Name)
+		}
+	}
+}
+
+func TestPicklesReturnsNilForDocumentWithoutFeature(t *testing.T) {
+	if got := Pickles(&GherkinDocument{}, "empty.feature", ""); got != nil {
+		t.Errorf("Pickles = %v, want nil", got)
+	}
+}